@@ -0,0 +1,76 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// failingWriteFS wraps a WriteFileFS and fails the first Rename whose destination base
+// name matches failRenameWhenDstBase, so tests can simulate an error partway through
+// writeAtomic's commit.
+type failingWriteFS struct {
+	WriteFileFS
+	failRenameWhenDstBase string
+}
+
+func (f failingWriteFS) Rename(oldPath, newPath string) error {
+	if filepath.Base(newPath) == f.failRenameWhenDstBase {
+		return fmt.Errorf("injected failure renaming into %s", newPath)
+	}
+	return f.WriteFileFS.Rename(oldPath, newPath)
+}
+
+// TestLocalPackageReadWriter_Write_AtomicRollbackOnCommitFailure verifies that when
+// Atomic is set and the commit fails partway through -- here, moving a brand-new file
+// into place after the existing file has already been moved aside (and, depending on map
+// iteration order, possibly replaced) -- Write leaves
+// PackagePath exactly as it was before the call, with no partially-written files and no
+// leftover staging directory.
+func TestLocalPackageReadWriter_Write_AtomicRollbackOnCommitFailure(t *testing.T) {
+	dir := t.TempDir()
+	original := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: keep\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep.yaml"), original, 0o644))
+
+	rw := &LocalPackageReadWriter{PackagePath: dir, Atomic: true}
+	nodes, err := rw.Read()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	// Replace keep.yaml's content and add a brand-new file, so the commit must both
+	// replace an existing file and create a new one.
+	updated := nodes[0].Copy()
+	require.NoError(t, updated.PipeE(yaml.SetAnnotation("updated", "true")))
+
+	newNode := yaml.MustParse("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: brandnew\n")
+	require.NoError(t, newNode.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, "new.yaml")))
+
+	rw.WriteFileSystem = failingWriteFS{WriteFileFS: osWriteFS{}, failRenameWhenDstBase: "new.yaml"}
+
+	err = rw.Write([]*yaml.RNode{updated, newNode})
+	require.Error(t, err)
+
+	after, err := os.ReadFile(filepath.Join(dir, "keep.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, original, after, "keep.yaml must be restored to its pre-Write content")
+
+	_, err = os.Stat(filepath.Join(dir, "new.yaml"))
+	assert.True(t, os.IsNotExist(err), "new.yaml must not have been left behind")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "PackagePath must hold only keep.yaml")
+
+	parentEntries, err := os.ReadDir(filepath.Dir(dir))
+	require.NoError(t, err)
+	assert.Len(t, parentEntries, 1, "no leftover staging directory should remain in PackagePath's parent")
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalPackageReader_ReadPackage_CollectErrorsPartialResult verifies that when
+// CollectErrors is set, ReadPackage still builds and returns a Package tree from the files
+// that did parse, alongside a *BadPackageError describing the ones that didn't -- rather
+// than discarding everything it had read so far.
+func TestLocalPackageReader_ReadPackage_CollectErrorsPartialResult(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Kptfile": &fstest.MapFile{Data: []byte(
+			"apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: root\n")},
+		"good.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: good\n")},
+		"bad.yaml": &fstest.MapFile{Data: []byte("not: [valid yaml")},
+	}
+
+	pkg, err := (LocalPackageReader{
+		PackagePath:   ".",
+		FileSystem:    fsys,
+		CollectErrors: true,
+	}).ReadPackage()
+	require.Error(t, err)
+
+	var badPkgErr *BadPackageError
+	require.ErrorAs(t, err, &badPkgErr)
+	require.Len(t, badPkgErr.Errors, 1)
+	assert.Equal(t, "bad.yaml", badPkgErr.Errors[0].Path)
+
+	require.NotNil(t, pkg)
+	assert.Equal(t, "root", pkg.Name)
+	require.Len(t, pkg.Resources, 1)
+	assert.Equal(t, "good", pkg.Resources[0].GetName())
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// MetadataMerger merges a parent package's effective (already merged) metadata into one
+// of its subpackages when LocalPackageReader.ReadPackage is building a Package tree with
+// MergeSubpackageMetadata set.
+type MetadataMerger interface {
+	// Merge returns the effective metadata for a subpackage given its own local
+	// package-metadata file (local, nil if it has none) and its parent's effective
+	// metadata file (parent, nil for the root package). Merge must not mutate local or
+	// parent. A nil, nil return leaves the subpackage's MetaFile as local.
+	Merge(local, parent *yaml.RNode) (*yaml.RNode, error)
+}
+
+// DefaultMetadataMerger propagates a parent package's openAPI.definitions (setters) down
+// into subpackages, the same way Kptfile openAPI blocks are merged when a package is
+// fetched with its upstream: entries already present on the subpackage are left alone,
+// entries present only on the parent are copied in, and a subpackage with no definitions
+// of its own falls back to the parent's wholesale.
+type DefaultMetadataMerger struct{}
+
+func (DefaultMetadataMerger) Merge(local, parent *yaml.RNode) (*yaml.RNode, error) {
+	if parent == nil {
+		// root package, or a merger invoked without a parent -- nothing to propagate.
+		return local, nil
+	}
+
+	parentDefs, err := parent.Pipe(yaml.Lookup("openAPI", "definitions"))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if parentDefs == nil {
+		// parent has no setters to propagate.
+		return local, nil
+	}
+	if local == nil {
+		// the subpackage has no metadata file of its own to merge into.
+		return nil, nil
+	}
+
+	localDefs, err := local.Pipe(yaml.Lookup("openAPI", "definitions"))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if localDefs == nil {
+		// no defs on the destination -- fall back to the parent's wholesale.
+		merged := local.Copy()
+		openAPI, err := merged.Pipe(yaml.LookupCreate(yaml.MappingNode, "openAPI"))
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if _, err := openAPI.Pipe(yaml.SetField("definitions", parentDefs.Copy())); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return merged, nil
+	}
+
+	// The subpackage already defines some setters of its own -- merge in only the
+	// parent's entries that aren't already present locally. A subpackage-local override
+	// always wins.
+	merged := local.Copy()
+	mergedDefs, err := merged.Pipe(yaml.Lookup("openAPI", "definitions"))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	err = parentDefs.VisitFields(func(field *yaml.MapNode) error {
+		key := yaml.GetValue(field.Key)
+		existing, err := mergedDefs.Pipe(yaml.Lookup(key))
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if existing != nil {
+			return nil
+		}
+		_, err = mergedDefs.Pipe(yaml.SetField(key, field.Value.Copy()))
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return merged, nil
+}
+
+// mergeSubpackageMetadata walks pkg's tree top-down, replacing each non-root package's
+// MetaFile with the result of merging it against its parent's already-merged MetaFile.
+func mergeSubpackageMetadata(pkg, parent *Package, merger MetadataMerger) error {
+	if parent != nil {
+		merged, err := merger.Merge(pkg.MetaFile, parent.MetaFile)
+		if err != nil {
+			return errors.WrapPrefixf(err, pkg.RelPath)
+		}
+		if merged != nil {
+			if err := pkg.setMetaFile(merged); err != nil {
+				return errors.WrapPrefixf(err, pkg.RelPath)
+			}
+		}
+	}
+	for _, sub := range pkg.Subpackages {
+		if err := mergeSubpackageMetadata(sub, pkg, merger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
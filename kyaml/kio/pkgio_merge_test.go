@@ -0,0 +1,148 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func setterValue(t *testing.T, meta *yaml.RNode, name string) string {
+	t.Helper()
+	value, err := meta.Pipe(yaml.Lookup("openAPI", "definitions", name, "x-k8s-cli", "setter", "value"))
+	require.NoError(t, err)
+	require.NotNil(t, value, "setter %s not found", name)
+	return yaml.GetValue(value)
+}
+
+// TestDefaultMetadataMerger_NoParentDefs covers the edge case where the parent has no
+// openAPI.definitions to propagate: Merge must return local unchanged.
+func TestDefaultMetadataMerger_NoParentDefs(t *testing.T) {
+	parent := yaml.MustParse("apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: parent\n")
+	local := yaml.MustParse("apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: child\n")
+
+	merged, err := (DefaultMetadataMerger{}).Merge(local, parent)
+	require.NoError(t, err)
+	assert.Same(t, local, merged)
+}
+
+// TestDefaultMetadataMerger_NoLocalDefsFallsBackWholesale covers the edge case where the
+// subpackage has no openAPI.definitions of its own: Merge must fall back to the parent's
+// wholesale, without mutating local.
+func TestDefaultMetadataMerger_NoLocalDefsFallsBackWholesale(t *testing.T) {
+	parent := yaml.MustParse(`
+apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: parent
+openAPI:
+  definitions:
+    io.k8s.cli.setters.replicas:
+      x-k8s-cli:
+        setter:
+          name: replicas
+          value: "3"
+`)
+	local := yaml.MustParse(`
+apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: child
+`)
+
+	merged, err := (DefaultMetadataMerger{}).Merge(local, parent)
+	require.NoError(t, err)
+	require.NotNil(t, merged)
+	assert.Equal(t, "3", setterValue(t, merged, "io.k8s.cli.setters.replicas"))
+
+	// local itself must be untouched.
+	untouched, err := local.Pipe(yaml.Lookup("openAPI"))
+	require.NoError(t, err)
+	assert.Nil(t, untouched)
+}
+
+// TestDefaultMetadataMerger_DoesNotOverwriteLocalOverride covers the edge case where the
+// subpackage already defines some setters: Merge must copy in only the parent's entries
+// that are absent locally, and must never overwrite a subpackage-local override.
+func TestDefaultMetadataMerger_DoesNotOverwriteLocalOverride(t *testing.T) {
+	parent := yaml.MustParse(`
+apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: parent
+openAPI:
+  definitions:
+    io.k8s.cli.setters.replicas:
+      x-k8s-cli:
+        setter:
+          name: replicas
+          value: "3"
+    io.k8s.cli.setters.image:
+      x-k8s-cli:
+        setter:
+          name: image
+          value: "nginx"
+`)
+	local := yaml.MustParse(`
+apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: child
+openAPI:
+  definitions:
+    io.k8s.cli.setters.replicas:
+      x-k8s-cli:
+        setter:
+          name: replicas
+          value: "5"
+`)
+
+	merged, err := (DefaultMetadataMerger{}).Merge(local, parent)
+	require.NoError(t, err)
+
+	assert.Equal(t, "5", setterValue(t, merged, "io.k8s.cli.setters.replicas"), "local override must win")
+	assert.Equal(t, "nginx", setterValue(t, merged, "io.k8s.cli.setters.image"), "parent-only setter must be propagated")
+
+	// local itself must be untouched.
+	assert.Equal(t, "5", setterValue(t, local, "io.k8s.cli.setters.replicas"))
+	localImage, err := local.Pipe(yaml.Lookup("openAPI", "definitions", "io.k8s.cli.setters.image"))
+	require.NoError(t, err)
+	assert.Nil(t, localImage)
+}
+
+// TestDefaultMetadataMerger_NoLocalMetadataFile covers Merge's contract when a subpackage
+// has no package-metadata file of its own: there is nothing to merge into, so Merge
+// returns nil, nil even though the parent has definitions to propagate.
+func TestDefaultMetadataMerger_NoLocalMetadataFile(t *testing.T) {
+	parent := yaml.MustParse(`
+apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: parent
+openAPI:
+  definitions:
+    io.k8s.cli.setters.replicas:
+      x-k8s-cli:
+        setter:
+          name: replicas
+          value: "3"
+`)
+
+	merged, err := (DefaultMetadataMerger{}).Merge(nil, parent)
+	require.NoError(t, err)
+	assert.Nil(t, merged)
+}
+
+// TestDefaultMetadataMerger_NoParent covers the root-package case: with no parent to merge
+// from, Merge returns local unchanged.
+func TestDefaultMetadataMerger_NoParent(t *testing.T) {
+	local := yaml.MustParse("apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: root\n")
+
+	merged, err := (DefaultMetadataMerger{}).Merge(local, nil)
+	require.NoError(t, err)
+	assert.Same(t, local, merged)
+}
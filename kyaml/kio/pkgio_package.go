@@ -0,0 +1,265 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Package is a parsed KRM package: its root path, the parsed package-metadata file (e.g.
+// Kptfile) for that root, and the subpackages discovered beneath it. It lets callers stop
+// reimplementing "find the Kptfile, parse it, walk subpackages" on top of the flat
+// []*yaml.RNode returned by LocalPackageReader.Read, and instead get the tree directly
+// from LocalPackageReader.ReadPackage.
+type Package struct {
+	// RelPath is this package's path relative to the package that was read, using
+	// forward slashes. The root package's RelPath is ".".
+	RelPath string
+
+	// MetaFile is the parsed package-metadata file for this package (e.g. Kptfile), or
+	// nil if the package directory has no such file.
+	MetaFile *yaml.RNode
+
+	// Name is read from MetaFile's metadata.name field, if MetaFile is present.
+	Name string
+
+	// Upstream is MetaFile's upstream field, if present.
+	Upstream *yaml.RNode
+
+	// OpenAPI is MetaFile's openAPI field (setters and definitions), if present.
+	OpenAPI *yaml.RNode
+
+	// Pipeline is MetaFile's pipeline field, if present.
+	Pipeline *yaml.RNode
+
+	// Resources are the ResourceNodes that belong directly to this package -- i.e. whose
+	// path annotation names a file under this package's directory that isn't itself owned
+	// by a subpackage. It excludes MetaFile.
+	Resources []*yaml.RNode
+
+	// Subpackages are the child packages discovered directly under this package.
+	Subpackages []*Package
+}
+
+// ReadPackage reads the package rooted at r.PackagePath into a Package tree. Each
+// package's metadata file (named r.PackageFileName, defaulting to "Kptfile") is parsed
+// into Package's typed fields, in addition to the flat []*yaml.RNode that Read returns.
+// ReadPackage always descends into subpackages, regardless of r.IncludeSubpackages.
+//
+// If r.MergeSubpackageMetadata is set, each subpackage's MetaFile is replaced with the
+// result of merging it against its parent's (already merged) MetaFile, using
+// r.MetadataMerger (DefaultMetadataMerger if unset), so downstream functions such as
+// setters and substitutions see one consistent, effective view of each package's
+// metadata rather than having to resolve the hierarchy themselves.
+func (r LocalPackageReader) ReadPackage() (*Package, error) {
+	if r.PackageFileName == "" {
+		r.PackageFileName = "Kptfile"
+	}
+	r.IncludeSubpackages = true
+	// Read only returns files matching MatchFilesGlob, so add the metadata file's name to it.
+	if len(r.MatchFilesGlob) == 0 {
+		r.MatchFilesGlob = DefaultMatch
+	}
+	r.MatchFilesGlob = append([]string{r.PackageFileName}, r.MatchFilesGlob...)
+
+	nodes, err := r.Read()
+	// With CollectErrors set, Read returns a *BadPackageError alongside the nodes it did
+	// manage to parse rather than aborting outright; keep building the tree from those so
+	// the caller still gets a usable (if incomplete) Package back.
+	badPackageErr, ok := err.(*BadPackageError)
+	if err != nil && !ok {
+		return nil, err
+	}
+
+	pkg, err := newPackageTree(nodes, r.PackageFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.MergeSubpackageMetadata {
+		merger := r.MetadataMerger
+		if merger == nil {
+			merger = DefaultMetadataMerger{}
+		}
+		if err := mergeSubpackageMetadata(pkg, nil, merger); err != nil {
+			return nil, err
+		}
+	}
+
+	if badPackageErr != nil {
+		return pkg, badPackageErr
+	}
+	return pkg, nil
+}
+
+// newPackageTree groups nodes by the package they belong to -- the nearest ancestor
+// directory (including the node's own directory) that contains packageFileName, falling
+// back to the root package -- and assembles the resulting tree rooted at ".".
+func newPackageTree(nodes []*yaml.RNode, packageFileName string) (*Package, error) {
+	type locatedNode struct {
+		node *yaml.RNode
+		dir  string
+		base string
+	}
+
+	packageDirs := map[string]bool{".": true}
+	located := make([]locatedNode, 0, len(nodes))
+	for _, n := range nodes {
+		p, _, err := kioutil.GetFileAnnotations(n)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		dir, base := packageDirAndBase(p)
+		if base == packageFileName {
+			packageDirs[dir] = true
+		}
+		located = append(located, locatedNode{node: n, dir: dir, base: base})
+	}
+
+	packages := make(map[string]*Package, len(packageDirs))
+	for dir := range packageDirs {
+		packages[dir] = &Package{RelPath: dir}
+	}
+
+	for _, ln := range located {
+		owner := packages[nearestPackageDir(ln.dir, packageDirs)]
+		if ln.base == packageFileName {
+			if err := owner.setMetaFile(ln.node); err != nil {
+				return nil, errors.WrapPrefixf(err, path.Join(ln.dir, ln.base))
+			}
+			continue
+		}
+		owner.Resources = append(owner.Resources, ln.node)
+	}
+
+	dirs := make([]string, 0, len(packageDirs))
+	for dir := range packageDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if dir == "." {
+			continue
+		}
+		parentDir := nearestPackageDir(path.Dir(dir), packageDirs)
+		parent := packages[parentDir]
+		parent.Subpackages = append(parent.Subpackages, packages[dir])
+	}
+	for _, pkg := range packages {
+		sort.Slice(pkg.Subpackages, func(i, j int) bool {
+			return pkg.Subpackages[i].RelPath < pkg.Subpackages[j].RelPath
+		})
+	}
+	return packages["."], nil
+}
+
+// packageDirAndBase splits a path annotation into its containing directory (using "."
+// for the package root) and base file name.
+func packageDirAndBase(p string) (dir, base string) {
+	p = path.Clean(p)
+	dir = path.Dir(p)
+	if dir == "" {
+		dir = "."
+	}
+	return dir, path.Base(p)
+}
+
+// nearestPackageDir walks up from dir until it finds a directory present in packageDirs,
+// stopping at "." (the root package), which is always present.
+func nearestPackageDir(dir string, packageDirs map[string]bool) string {
+	for {
+		if packageDirs[dir] {
+			return dir
+		}
+		if dir == "." {
+			return dir
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// setMetaFile parses n -- the package-metadata file for p -- into p's typed fields.
+func (p *Package) setMetaFile(n *yaml.RNode) error {
+	p.MetaFile = n
+
+	meta, err := n.GetMeta()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	p.Name = meta.Name
+
+	upstream, err := n.Pipe(yaml.Lookup("upstream"))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	p.Upstream = upstream
+
+	openAPI, err := n.Pipe(yaml.Lookup("openAPI"))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	p.OpenAPI = openAPI
+
+	pipeline, err := n.Pipe(yaml.Lookup("pipeline"))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	p.Pipeline = pipeline
+
+	return nil
+}
+
+// Walk calls fn for p and then for every package beneath it, depth-first in pre-order,
+// visiting each package's direct subpackages in RelPath order. Walk stops and returns the first non-nil error returned by fn.
+func (p *Package) Walk(fn func(*Package) error) error {
+	if err := fn(p); err != nil {
+		return err
+	}
+	for _, sub := range p.Subpackages {
+		if err := sub.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subpackage returns the subpackage at relPath, relative to p, or nil if no such
+// subpackage was discovered. relPath may name a direct or transitive subpackage -- e.g.
+// "foo/bar" looks up p's subpackage "foo", then that package's subpackage "bar".
+func (p *Package) Subpackage(relPath string) *Package {
+	relPath = path.Clean(relPath)
+	if relPath == "." {
+		return p
+	}
+
+	// RelPath is relative to the package that was read, not to p.
+	target := path.Join(p.RelPath, relPath)
+	for _, sub := range p.Subpackages {
+		if sub.RelPath == target {
+			return sub
+		}
+		if strings.HasPrefix(target, sub.RelPath+"/") {
+			return sub.Subpackage(strings.TrimPrefix(target, sub.RelPath+"/"))
+		}
+	}
+	return nil
+}
+
+// AllResources returns p's own Resources followed by the Resources of every subpackage
+// beneath it, in the same pre-order used by Walk. Use Resources directly to iterate only
+// the nodes owned by this package.
+func (p *Package) AllResources() []*yaml.RNode {
+	resources := make([]*yaml.RNode, 0, len(p.Resources))
+	_ = p.Walk(func(pkg *Package) error {
+		resources = append(resources, pkg.Resources...)
+		return nil
+	})
+	return resources
+}
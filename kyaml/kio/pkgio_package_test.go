@@ -0,0 +1,51 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalPackageReader_ReadPackage_SubpackageAttribution verifies that ReadPackage
+// attributes each resource to the nearest ancestor directory containing a Kptfile, and
+// that a directory without its own Kptfile is not treated as a subpackage boundary -- its
+// resources roll up into the nearest package that owns it.
+func TestLocalPackageReader_ReadPackage_SubpackageAttribution(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Kptfile": &fstest.MapFile{Data: []byte(
+			"apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: root\n")},
+		"a.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")},
+		"sub/Kptfile": &fstest.MapFile{Data: []byte(
+			"apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: sub\n")},
+		"sub/b.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n")},
+		"sub/nested/c.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n")},
+	}
+
+	pkg, err := (LocalPackageReader{PackagePath: ".", FileSystem: fsys}).ReadPackage()
+	require.NoError(t, err)
+
+	assert.Equal(t, ".", pkg.RelPath)
+	assert.Equal(t, "root", pkg.Name)
+	require.Len(t, pkg.Resources, 1)
+	assert.Equal(t, "a", pkg.Resources[0].GetName())
+
+	require.Len(t, pkg.Subpackages, 1)
+	sub := pkg.Subpackage("sub")
+	require.NotNil(t, sub)
+	assert.Equal(t, "sub", sub.Name)
+
+	// "nested" has no Kptfile of its own, so it's not a subpackage -- its resource is
+	// attributed to "sub", the nearest ancestor package.
+	assert.Empty(t, sub.Subpackages)
+	require.Len(t, sub.Resources, 2)
+	names := []string{sub.Resources[0].GetName(), sub.Resources[1].GetName()}
+	assert.ElementsMatch(t, []string{"b", "c"}, names)
+}
@@ -5,8 +5,12 @@ package kio
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
+	"sort"
+	"sync"
 
 	"sigs.k8s.io/kustomize/kyaml/errors"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
@@ -70,9 +74,37 @@ type LocalPackageReadWriter struct {
 	// NoDeleteFiles if set to true, LocalPackageReadWriter won't delete any files
 	NoDeleteFiles bool `yaml:"noDeleteFiles,omitempty"`
 
+	// Atomic, when set, makes Write transactional: every new/updated file is staged to a
+	// temporary directory next to PackagePath, and committed into place only once
+	// staging succeeds. The commit itself is two-phase and fully reversible -- replaced
+	// or deleted files are backed up before anything is moved into PackagePath, and
+	// staged files are moved in only once every backup has succeeded -- so a failure at
+	// any point (staging, or either commit phase) unwinds whatever that attempt had
+	// already done and leaves every file in PackagePath as it was before Write was called,
+	// rather than ending up with only some of the files rewritten. Directories created to
+	// hold new files during a failed commit are not removed.
+	Atomic bool `yaml:"atomic,omitempty"`
+
+	// WriteFileSystem is the WriteFileFS that Write uses to delete superseded files and,
+	// in Atomic mode, to stage and commit rewritten ones. When nil (the default), Write
+	// uses the local OS filesystem, matching the original behavior.
+	//
+	// This does not yet cover the per-resource file writes LocalPackageWriter performs on
+	// Write's behalf -- see the TODO on Write -- so setting WriteFileSystem to something
+	// other than the OS filesystem is not yet sufficient to make Write fully non-OS-backed.
+	WriteFileSystem WriteFileFS `yaml:"-"`
+
 	files sets.String
 }
 
+// writeFS returns r.WriteFileSystem, defaulting to the OS filesystem when unset.
+func (r *LocalPackageReadWriter) writeFS() WriteFileFS {
+	if r.WriteFileSystem != nil {
+		return r.WriteFileSystem
+	}
+	return osWriteFS{}
+}
+
 func (r *LocalPackageReadWriter) Read() ([]*yaml.RNode, error) {
 	nodes, err := LocalPackageReader{
 		PackagePath:         r.PackagePath,
@@ -95,6 +127,16 @@ func (r *LocalPackageReadWriter) Read() ([]*yaml.RNode, error) {
 	return nodes, nil
 }
 
+// Write writes nodes back to PackagePath, deleting any file all of whose Resources have
+// been removed from nodes.
+//
+// TODO(nurtaklim/kustomize#chunk0-2): only the steps Write performs directly -- deleting
+// superseded files, and, in Atomic mode, staging/committing rewritten ones -- go through
+// WriteFileSystem. The actual per-resource file writes are still delegated to
+// LocalPackageWriter, which talks to the OS filesystem directly and isn't threaded
+// through this abstraction; that type lives outside this chunk of the tree. Until it is
+// updated to accept a write-capable fs.FS, WriteFileSystem only lets a caller redirect
+// deletion and atomic staging/commit, not where the rewritten Resources themselves land.
 func (r *LocalPackageReadWriter) Write(nodes []*yaml.RNode) error {
 	newFiles, err := r.getFiles(nodes)
 	if err != nil {
@@ -104,6 +146,12 @@ func (r *LocalPackageReadWriter) Write(nodes []*yaml.RNode) error {
 	for k := range r.SetAnnotations {
 		clear = append(clear, k)
 	}
+	deleteFiles := r.files.Difference(newFiles)
+
+	if r.Atomic {
+		return r.writeAtomic(nodes, clear, newFiles, deleteFiles)
+	}
+
 	err = LocalPackageWriter{
 		PackagePath:           r.PackagePath,
 		ClearAnnotations:      clear,
@@ -112,15 +160,122 @@ func (r *LocalPackageReadWriter) Write(nodes []*yaml.RNode) error {
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	deleteFiles := r.files.Difference(newFiles)
+	fsys := r.writeFS()
+	for f := range deleteFiles {
+		if err = fsys.Remove(filepath.Join(r.PackagePath, f)); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// writeAtomic is the Atomic path for Write. It stages every new/updated file to a
+// temporary directory next to PackagePath, then commits in two reversible phases: phase
+// one moves every file Write is about to replace or delete out of PackagePath and into a
+// backup directory (still inside the staging area), and phase two moves the staged files
+// into place. If either phase fails partway through, every step already taken in that
+// commit is unwound -- backed-up files are moved back, newly-placed files are removed --
+// before the staging directory (backups included) is discarded. On any error, every file
+// under PackagePath ends up as it was before Write was called; directories that phase two
+// created for new files are left in place.
+func (r *LocalPackageReadWriter) writeAtomic(
+	nodes []*yaml.RNode, clearAnnotations []string, newFiles, deleteFiles sets.String) error {
+	fsys := r.writeFS()
+	stagingDir, err := fsys.TempDir(filepath.Join(r.PackagePath, ".."), ".kio-tx-*")
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	defer fsys.RemoveAll(stagingDir)
+
+	if err := (LocalPackageWriter{
+		PackagePath:           stagingDir,
+		ClearAnnotations:      clearAnnotations,
+		KeepReaderAnnotations: r.KeepReaderAnnotations,
+	}).Write(nodes); err != nil {
+		return errors.Wrap(err)
+	}
+
+	backupDir := filepath.Join(stagingDir, ".orig")
+	backedUp := sets.String{}
+
+	touched := sets.String{}
+	for f := range newFiles {
+		touched.Insert(f)
+	}
 	for f := range deleteFiles {
-		if err = os.Remove(filepath.Join(r.PackagePath, f)); err != nil {
+		touched.Insert(f)
+	}
+
+	// Phase 1: move every file Write is about to replace or delete out of PackagePath
+	// and into backupDir, preserving its original content. This is fully reversible --
+	// if any move fails partway through, every prior move in this phase is undone and
+	// PackagePath is left exactly as it was.
+	for f := range touched {
+		dst := filepath.Join(r.PackagePath, f)
+		exists, err := fsys.Exists(dst)
+		if err != nil {
+			r.restoreBackups(fsys, backupDir, backedUp)
+			return errors.Wrap(err)
+		}
+		if !exists {
+			continue
+		}
+		backup := filepath.Join(backupDir, f)
+		if err := fsys.MkdirAll(filepath.Dir(backup)); err != nil {
+			r.restoreBackups(fsys, backupDir, backedUp)
+			return errors.Wrap(err)
+		}
+		if err := fsys.Rename(dst, backup); err != nil {
+			r.restoreBackups(fsys, backupDir, backedUp)
+			return errors.Wrap(err)
+		}
+		backedUp.Insert(f)
+	}
+
+	// Phase 2: move every new/updated file from the staging directory into place. Every
+	// destination is now guaranteed to be clear -- phase 1 either backed it up or it
+	// never existed -- so the only way this can fail is an OS-level error on an
+	// already-proven-good target, which we still unwind below rather than leaving
+	// partially committed.
+	moved := sets.String{}
+	for f := range newFiles {
+		dst := filepath.Join(r.PackagePath, f)
+		if err := fsys.MkdirAll(filepath.Dir(dst)); err != nil {
+			r.rollbackCommit(fsys, backupDir, moved, backedUp)
 			return errors.Wrap(err)
 		}
+		if err := fsys.Rename(filepath.Join(stagingDir, f), dst); err != nil {
+			r.rollbackCommit(fsys, backupDir, moved, backedUp)
+			return errors.Wrap(err)
+		}
+		moved.Insert(f)
 	}
+
+	// deleteFiles were already removed from PackagePath in phase 1 (moved into
+	// backupDir); letting the deferred fsys.RemoveAll(stagingDir) above discard that
+	// backup on return is what makes the deletion permanent.
 	return nil
 }
 
+// restoreBackups undoes phase 1 of writeAtomic: every file in backedUp is moved from
+// backupDir back to its original location under r.PackagePath.
+func (r *LocalPackageReadWriter) restoreBackups(fsys WriteFileFS, backupDir string, backedUp sets.String) {
+	for f := range backedUp {
+		_ = fsys.Rename(filepath.Join(backupDir, f), filepath.Join(r.PackagePath, f))
+	}
+}
+
+// rollbackCommit undoes phase 2 of writeAtomic (removing every file already moved into
+// place) and then phase 1 (restoring every backed-up file), leaving every file under
+// r.PackagePath as it was before Write was called. Directories created in phase 2 are not
+// removed.
+func (r *LocalPackageReadWriter) rollbackCommit(fsys WriteFileFS, backupDir string, moved, backedUp sets.String) {
+	for f := range moved {
+		_ = fsys.Remove(filepath.Join(r.PackagePath, f))
+	}
+	r.restoreBackups(fsys, backupDir, backedUp)
+}
+
 func (r *LocalPackageReadWriter) getFiles(nodes []*yaml.RNode) (sets.String, error) {
 	val := sets.String{}
 	for _, n := range nodes {
@@ -163,6 +318,41 @@ type LocalPackageReader struct {
 
 	// SetAnnotations are annotations to set on the Resources as they are read.
 	SetAnnotations map[string]string `yaml:"setAnnotations,omitempty"`
+
+	// Concurrency sets the number of files that may be opened and parsed concurrently.
+	// Directory traversal -- including .krmignore evaluation and Kptfile-based
+	// subpackage detection -- always happens sequentially on a single goroutine; only the
+	// work of reading and parsing the files discovered by that walk is parallelized across
+	// a bounded worker pool. Results are sorted by path before being returned so that
+	// downstream filters and writers see a deterministic ordering regardless of how the
+	// work was scheduled.
+	//
+	// A value <= 1 (the default) preserves the original sequential Read behavior.
+	Concurrency int `yaml:"concurrency,omitempty"`
+
+	// FileSystem is the fs.FS that Read walks to discover and open package files. It is
+	// assumed to be rooted at the package itself (i.e. the Kptfile, if any, lives at its
+	// root). When nil, Read falls back to os.DirFS rooted at PackagePath, which preserves
+	// the original OS-backed behavior. Setting FileSystem lets a caller point the reader at
+	// an in-memory FS, a zip.Reader, an OCI-layer FS, or a testing FS without touching disk.
+	FileSystem fs.FS `yaml:"-"`
+
+	// MergeSubpackageMetadata configures ReadPackage to merge each subpackage's
+	// package-metadata file with its parent's, using MetadataMerger. It has no effect on
+	// Read, which never merges package-metadata files.
+	MergeSubpackageMetadata bool `yaml:"-"`
+
+	// MetadataMerger merges parent package metadata into subpackages when
+	// MergeSubpackageMetadata is set. Defaults to DefaultMetadataMerger.
+	MetadataMerger MetadataMerger `yaml:"-"`
+
+	// CollectErrors configures Read to continue past a file whose YAML fails to parse
+	// instead of aborting the whole package walk. Read does not check documents for a
+	// missing apiVersion or kind, so ErrorIfNonResources adds no failures here. Every such failure
+	// is collected into the returned *BadPackageError, alongside the ResourceNodes
+	// successfully read from every other file, so a tool can report every problem in a
+	// package at once rather than fixing and re-running one file at a time.
+	CollectErrors bool `yaml:"collectErrors,omitempty"`
 }
 
 var _ Reader = LocalPackageReader{}
@@ -176,44 +366,46 @@ func (r LocalPackageReader) Read() ([]*yaml.RNode, error) {
 	if r.PackagePath == "" {
 		return nil, fmt.Errorf("must specify package path")
 	}
-
-	// use slash for path
-	r.PackagePath = filepath.ToSlash(r.PackagePath)
 	if len(r.MatchFilesGlob) == 0 {
 		r.MatchFilesGlob = DefaultMatch
 	}
 
-	var operand ResourceNodeSlice
-	var pathRelativeTo string
-	var err error
-	ignoreFilesMatcher := &IgnoreFilesMatcher{}
-	r.PackagePath, err = filepath.Abs(r.PackagePath)
+	fsys, root, osBaseDir, err := r.resolveFileSystem()
 	if err != nil {
-		return nil, errors.Wrap(err)
+		return nil, err
 	}
-	err = filepath.Walk(r.PackagePath, func(
-		path string, info os.FileInfo, err error) error {
+	// osPath turns a path relative to fsys into the real OS path IgnoreFilesMatcher needs
+	// to read a .krmignore file from, or "" when fsys isn't OS-backed (r.FileSystem was
+	// set) and no such path exists.
+	osPath := func(p string) string {
+		if osBaseDir == "" {
+			return ""
+		}
+		return filepath.Join(osBaseDir, p)
+	}
+
+	var files []pkgFileToRead
+	ignoreFilesMatcher := &IgnoreFilesMatcher{}
+	err = fs.WalkDir(fsys, root, func(
+		path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return errors.Wrap(err)
 		}
 
-		// is this the user specified path?
-		if path == r.PackagePath {
-			if info.IsDir() {
-				// skip the root package directory, but check for a
-				// .krmignore file first.
-				pathRelativeTo = r.PackagePath
-				return ignoreFilesMatcher.readIgnoreFile(path)
+		// is this the root of the package?
+		if path == root && d.IsDir() {
+			// skip the root package directory, but check for a
+			// .krmignore file first. IgnoreFilesMatcher isn't fs.FS-aware yet, so this
+			// only has an OS path to check when the walk itself is OS-backed.
+			if p := osPath(path); p != "" {
+				return ignoreFilesMatcher.readIgnoreFile(p)
 			}
-
-			// user specified path is a file rather than a directory.
-			// make its path relative to its parent so it can be written to another file.
-			pathRelativeTo = filepath.Dir(r.PackagePath)
+			return nil
 		}
 
 		// check if we should skip the directory or file
-		if info.IsDir() {
-			return r.ShouldSkipDir(path, ignoreFilesMatcher)
+		if d.IsDir() {
+			return r.ShouldSkipDir(fsys, path, osPath(path), ignoreFilesMatcher)
 		}
 		if match, err := r.ShouldSkipFile(path, ignoreFilesMatcher); err != nil {
 			return err
@@ -222,27 +414,171 @@ func (r LocalPackageReader) Read() ([]*yaml.RNode, error) {
 			return nil
 		}
 
-		// get the relative path to file within the package so we can write the files back out
-		// to another location.
-		path, err = filepath.Rel(pathRelativeTo, path)
+		info, err := d.Info()
 		if err != nil {
-			return errors.WrapPrefixf(err, pathRelativeTo)
+			return errors.Wrap(err)
 		}
+		files = append(files, pkgFileToRead{relPath: path, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Concurrency > 1 {
+		return r.readFilesConcurrently(fsys, files)
+	}
+	return r.readFilesSequentially(fsys, files)
+}
+
+// resolveFileSystem returns the fs.FS that Read should walk, the path (relative to that
+// FS) at which the walk should start, and the real OS directory the walk's paths are
+// relative to -- or "" when r.FileSystem is set, since there is no such directory.
+// IgnoreFilesMatcher.readIgnoreFile still takes an OS path rather than an fs.FS-relative
+// one (see its call sites below), so that OS directory is what lets Read keep resolving
+// .krmignore files correctly for the common, disk-backed case.
+//
+// When r.FileSystem is set, the package is assumed to live at its root. Otherwise Read
+// defaults to os.DirFS rooted at PackagePath (or at its parent, when PackagePath names a
+// single file rather than a directory), preserving the original OS-backed behavior.
+func (r *LocalPackageReader) resolveFileSystem() (fs.FS, string, string, error) {
+	if r.FileSystem != nil {
+		return r.FileSystem, ".", "", nil
+	}
 
-		r.initReaderAnnotations(path, info)
-		nodes, err := r.readFile(filepath.Join(pathRelativeTo, path), info)
+	packagePath, err := filepath.Abs(filepath.ToSlash(r.PackagePath))
+	if err != nil {
+		return nil, "", "", errors.Wrap(err)
+	}
+	r.PackagePath = packagePath
+
+	info, err := os.Stat(packagePath)
+	if err != nil {
+		return nil, "", "", errors.Wrap(err)
+	}
+	if info.IsDir() {
+		return os.DirFS(packagePath), ".", packagePath, nil
+	}
+	// the user specified path is a file rather than a directory; root the FS at its
+	// parent so the file's path relative to the FS is just its base name.
+	parent := filepath.Dir(packagePath)
+	return os.DirFS(parent), filepath.Base(packagePath), parent, nil
+}
+
+// pkgFileToRead identifies a single file discovered during the package walk that still
+// needs to be opened and parsed into ResourceNodes. relPath is relative to the fs.FS the
+// walk was run against.
+type pkgFileToRead struct {
+	relPath string
+	info    fs.FileInfo
+}
+
+// readFilesSequentially reads the files in the order they were discovered. This is the
+// original Read behavior and remains the default when Concurrency <= 1.
+func (r *LocalPackageReader) readFilesSequentially(fsys fs.FS, files []pkgFileToRead) (ResourceNodeSlice, error) {
+	var operand ResourceNodeSlice
+	var badFiles []*BadFileError
+	for _, f := range files {
+		r.initReaderAnnotations(f.relPath, f.info)
+		nodes, err := r.readFile(fsys, f.relPath, f.info)
 		if err != nil {
-			return errors.WrapPrefixf(err, filepath.Join(pathRelativeTo, path))
+			if r.CollectErrors {
+				badFiles = append(badFiles, newBadFileError(f.relPath, err))
+				continue
+			}
+			return nil, errors.WrapPrefixf(err, f.relPath)
 		}
 		operand = append(operand, nodes...)
-		return nil
+	}
+	if len(badFiles) > 0 {
+		return operand, &BadPackageError{Errors: badFiles}
+	}
+	return operand, nil
+}
+
+// readFilesConcurrently fans the files out across a bounded worker pool so that opening
+// and parsing many files in a large package does not serialize on disk I/O. Results are
+// collected per-file and then sorted by path (and original discovery order as a tie
+// breaker) so the returned slice is deterministic regardless of worker scheduling.
+func (r *LocalPackageReader) readFilesConcurrently(fsys fs.FS, files []pkgFileToRead) (ResourceNodeSlice, error) {
+	type result struct {
+		index int
+		path  string
+		nodes []*yaml.RNode
+		err   error
+	}
+
+	jobs := make(chan int)
+	results := make(chan result, len(files))
+
+	workers := r.Concurrency
+	if workers > len(files) {
+		workers = len(files)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := files[i]
+				// Each file gets its own copy of the reader annotations so concurrent
+				// reads don't race on the shared SetAnnotations map.
+				annotations := r.fileReaderAnnotations(f.relPath)
+				nodes, err := r.readFileWithAnnotations(fsys, f.relPath, annotations)
+				results <- result{index: i, path: f.relPath, nodes: nodes, err: err}
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	wg.Wait()
+	close(results)
+
+	collected := make([]result, 0, len(files))
+	var badFiles []*BadFileError
+	for res := range results {
+		if res.err != nil {
+			if r.CollectErrors {
+				badFiles = append(badFiles, newBadFileError(res.path, res.err))
+				continue
+			}
+			return nil, errors.WrapPrefixf(res.err, files[res.index].relPath)
+		}
+		collected = append(collected, res)
+	}
+	sort.Slice(collected, func(i, j int) bool {
+		if collected[i].path != collected[j].path {
+			return collected[i].path < collected[j].path
+		}
+		return collected[i].index < collected[j].index
 	})
-	return operand, err
+
+	var operand ResourceNodeSlice
+	for _, res := range collected {
+		operand = append(operand, res.nodes...)
+	}
+	if len(badFiles) > 0 {
+		sort.Slice(badFiles, func(i, j int) bool { return badFiles[i].Path < badFiles[j].Path })
+		return operand, &BadPackageError{Errors: badFiles}
+	}
+	return operand, nil
 }
 
-// readFile reads the ResourceNodes from a file
-func (r *LocalPackageReader) readFile(path string, _ os.FileInfo) ([]*yaml.RNode, error) {
-	f, err := os.Open(path)
+// readFile reads the ResourceNodes from a file in fsys
+func (r *LocalPackageReader) readFile(fsys fs.FS, path string, _ fs.FileInfo) ([]*yaml.RNode, error) {
+	return r.readFileWithAnnotations(fsys, path, r.SetAnnotations)
+}
+
+// readFileWithAnnotations reads the ResourceNodes from a file in fsys, annotating them
+// with the given annotations rather than r.SetAnnotations. This lets concurrent callers
+// avoid sharing (and racing on) the reader's SetAnnotations map.
+func (r *LocalPackageReader) readFileWithAnnotations(fsys fs.FS, path string, annotations map[string]string) ([]*yaml.RNode, error) {
+	f, err := fsys.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -252,7 +588,7 @@ func (r *LocalPackageReader) readFile(path string, _ os.FileInfo) ([]*yaml.RNode
 		DisableUnwrapping:     true,
 		Reader:                f,
 		OmitReaderAnnotations: r.OmitReaderAnnotations,
-		SetAnnotations:        r.SetAnnotations,
+		SetAnnotations:        annotations,
 	}
 	return rr.Read()
 }
@@ -266,7 +602,7 @@ func (r *LocalPackageReader) ShouldSkipFile(path string, matcher *IgnoreFilesMat
 
 	// check if the files are in scope
 	for _, g := range r.MatchFilesGlob {
-		if match, err := filepath.Match(g, filepath.Base(path)); err != nil {
+		if match, err := filepath.Match(g, pathpkg.Base(path)); err != nil {
 			return false, errors.Wrap(err)
 		} else if match {
 			return true, nil
@@ -285,8 +621,24 @@ func (r *LocalPackageReader) initReaderAnnotations(path string, _ os.FileInfo) {
 	}
 }
 
-// ShouldSkipDir returns a filepath.SkipDir if the directory should be skipped
-func (r *LocalPackageReader) ShouldSkipDir(path string, matcher *IgnoreFilesMatcher) error {
+// fileReaderAnnotations returns a private copy of r.SetAnnotations with the path
+// annotation for path set, suitable for passing to a single readFileWithAnnotations call
+// without mutating the shared r.SetAnnotations map.
+func (r *LocalPackageReader) fileReaderAnnotations(path string) map[string]string {
+	annotations := make(map[string]string, len(r.SetAnnotations)+1)
+	for k, v := range r.SetAnnotations {
+		annotations[k] = v
+	}
+	if !r.OmitReaderAnnotations {
+		annotations[kioutil.PathAnnotation] = path
+	}
+	return annotations
+}
+
+// ShouldSkipDir returns a filepath.SkipDir if the directory should be skipped. osDirPath
+// is path's real OS path, for IgnoreFilesMatcher.readIgnoreFile, or "" when fsys isn't
+// OS-backed (r.FileSystem was set) and no such path exists.
+func (r *LocalPackageReader) ShouldSkipDir(fsys fs.FS, path, osDirPath string, matcher *IgnoreFilesMatcher) error {
 	if r.PackageFileName == "" {
 		// If the folder is not a package, but covered by the .krmignore file,
 		// we skip it.
@@ -296,7 +648,7 @@ func (r *LocalPackageReader) ShouldSkipDir(path string, matcher *IgnoreFilesMatc
 		return nil
 	}
 	// check if this is a subpackage
-	_, err := os.Stat(filepath.Join(path, r.PackageFileName))
+	_, err := fs.Stat(fsys, pathpkg.Join(path, r.PackageFileName))
 	if os.IsNotExist(err) {
 		// Skip the folder if it is covered by the .krmignore file.
 		if matcher.matchDir(path) {
@@ -313,5 +665,10 @@ func (r *LocalPackageReader) ShouldSkipDir(path string, matcher *IgnoreFilesMatc
 	// a subpackage. So if we have found a package file in the folder and
 	// we should include subpackages, we don't check the .krmignore file. We
 	// do however check whether the package contains a .krmignore file.
-	return matcher.readIgnoreFile(path)
+	// IgnoreFilesMatcher isn't fs.FS-aware yet, so this only has something to check
+	// when the walk itself is OS-backed.
+	if osDirPath == "" {
+		return nil
+	}
+	return matcher.readIgnoreFile(osDirPath)
 }
@@ -0,0 +1,60 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+)
+
+func packageFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")},
+		"b/b.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n")},
+		"c/d/e.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: e\n")},
+		"c/d/f.yaml": &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: f\n")},
+	}
+}
+
+// TestLocalPackageReader_Read_ConcurrentMatchesSequential verifies that reading the same
+// package with Concurrency > 1 returns the same resources, in the same order, as the
+// default sequential Read, regardless of how its worker pool happens to schedule files.
+// The concurrent path sorts by path; for this package's file names that order is the same
+// as the sequential walk order.
+func TestLocalPackageReader_Read_ConcurrentMatchesSequential(t *testing.T) {
+	fsys := packageFS()
+
+	seqNodes, err := (LocalPackageReader{
+		PackagePath:        ".",
+		FileSystem:         fsys,
+		IncludeSubpackages: true,
+	}).Read()
+	require.NoError(t, err)
+
+	concNodes, err := (LocalPackageReader{
+		PackagePath:        ".",
+		FileSystem:         fsys,
+		IncludeSubpackages: true,
+		Concurrency:        4,
+	}).Read()
+	require.NoError(t, err)
+
+	require.Len(t, concNodes, len(seqNodes))
+	for i := range seqNodes {
+		seqPath, _, err := kioutil.GetFileAnnotations(seqNodes[i])
+		require.NoError(t, err)
+		concPath, _, err := kioutil.GetFileAnnotations(concNodes[i])
+		require.NoError(t, err)
+		assert.Equal(t, seqPath, concPath, "node %d out of order", i)
+		assert.Equal(t, seqNodes[i].GetName(), concNodes[i].GetName())
+	}
+}
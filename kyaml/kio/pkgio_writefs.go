@@ -0,0 +1,76 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kio
+
+import "os"
+
+// WriteFileFS is the write-capable filesystem LocalPackageReadWriter.Write uses to delete
+// superseded files and, in Atomic mode, to stage and commit rewritten ones. It is
+// satisfied by the OS filesystem (the default) as well as any caller-provided
+// implementation that can create directories, rename, and remove files -- e.g. to target
+// an in-memory or network-backed destination instead of local disk.
+//
+// This is a partial delivery of "abstract LocalPackageReader/Writer over an fs.FS":
+// LocalPackageWriter -- which performs the actual per-resource file writes that populate
+// a package directory -- lives outside this chunk of the tree, so it isn't threaded
+// through this abstraction here. Pointing WriteFileSystem elsewhere currently only
+// redirects the deletion and atomic staging/commit steps described above, not the
+// underlying per-resource file writes themselves; see the TODO on
+// LocalPackageReadWriter.Write. Completing the writer side requires a follow-up change to
+// LocalPackageWriter itself.
+type WriteFileFS interface {
+	// MkdirAll creates path and any missing parents, the way os.MkdirAll does.
+	MkdirAll(path string) error
+
+	// Rename moves oldPath to newPath, replacing newPath if it already exists, the way
+	// os.Rename does.
+	Rename(oldPath, newPath string) error
+
+	// Remove removes a single file at path, the way os.Remove does.
+	Remove(path string) error
+
+	// RemoveAll removes path and everything beneath it, the way os.RemoveAll does.
+	RemoveAll(path string) error
+
+	// TempDir creates a new temporary directory inside dir using pattern, the way
+	// os.MkdirTemp does, and returns its path.
+	TempDir(dir, pattern string) (string, error)
+
+	// Exists reports whether a file or directory exists at path.
+	Exists(path string) (bool, error)
+}
+
+// osWriteFS is the default WriteFileFS, backed by the local OS filesystem.
+type osWriteFS struct{}
+
+func (osWriteFS) MkdirAll(path string) error {
+	return os.MkdirAll(path, 0o755)
+}
+
+func (osWriteFS) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (osWriteFS) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (osWriteFS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (osWriteFS) TempDir(dir, pattern string) (string, error) {
+	return os.MkdirTemp(dir, pattern)
+}
+
+func (osWriteFS) Exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}